@@ -26,7 +26,7 @@ curl -sSL https://github.com/gobuffalo/packr/releases/download/v1.11.0/packr_1.1
 echo
 
 echo 'Compiling releaser...'
-GO_LDFLAGS="-X main.Version=$VERSION -X main.BuildDate=$TIMESTAMP"
+GO_LDFLAGS="-X main.Version=$VERSION -X main.BuildDate=$TIMESTAMP -X main.Commit=$(git rev-parse HEAD)"
 PREFIX='auklet-releaser'
 S3_PREFIX='auklet/c/releaser'
 GOOS=linux GOARCH=amd64 go build -ldflags "$GO_LDFLAGS" -o $PREFIX-linux-amd64-$VERSION_SIMPLE ./cmd/release
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/aukletio/Auklet-Releaser-C/config"
+)
+
+// A check is one step of the doctor report. It returns a short description
+// of what it found, or an error if the check failed. Failed optional checks
+// are reported as warnings.
+type check struct {
+	name     string
+	optional bool
+	run      func() (string, error)
+}
+
+// doctor runs checks of the releaser's environment, printing a pass/fail
+// report. If deployName is not empty, the executable and its debug file are
+// checked too. It returns false if any check failed.
+func doctor(baseURL, filename, deployName string) bool {
+	var cfg config.Config
+	checks := []check{
+		{"config", false, func() (string, error) {
+			var err error
+			cfg, err = config.GetConfig(baseURL, filename)
+			if err != nil {
+				return "", err
+			}
+			var missing []string
+			if cfg.AppID == "" {
+				missing = append(missing, "app ID")
+			}
+			if cfg.APIKey == "" {
+				missing = append(missing, "API key")
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("no %v found in the environment, config file or credentials",
+					strings.Join(missing, " or "))
+			}
+			return "app ID " + cfg.AppID, nil
+		}},
+		{"api", false, func() (string, error) {
+			return ping(cfg)
+		}},
+		{"git", true, func() (string, error) {
+			out, err := exec.Command("git", "rev-parse", "HEAD").CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("no commit hash will be sent: %v",
+					strings.TrimSpace(string(out)))
+			}
+			return "commit " + strings.TrimSpace(string(out)), nil
+		}},
+	}
+	if deployName != "" {
+		checks = append(checks, check{"deploy file", false, func() (string, error) {
+			f, err := elf.Open(deployName)
+			if err != nil {
+				return "", err
+			}
+			f.Close()
+			return deployName, nil
+		}}, check{"debug file", false, func() (string, error) {
+			debugName, err := findDebugFile(deployName)
+			if err != nil {
+				return "", err
+			}
+			f, err := elf.Open(debugName)
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			if _, err := f.DWARF(); err != nil {
+				return "", fmt.Errorf("%v: %v", debugName, err)
+			}
+			return debugName, nil
+		}})
+	}
+
+	ok := true
+	for _, c := range checks {
+		result, err := c.run()
+		if err != nil && c.optional {
+			fmt.Printf("WARN %-12v %v\n", c.name, err)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("FAIL %-12v %v\n", c.name, err)
+			ok = false
+			continue
+		}
+		fmt.Printf("PASS %-12v %v\n", c.name, result)
+	}
+	return ok
+}
+
+// ping sends an authenticated request to the releases API that uploads go
+// to, through the same client, proxy settings and TLS verification as a
+// release. Only a 2xx response shows that the API key was accepted.
+func ping(cfg config.Config) (string, error) {
+	if cfg.BaseURL == "" {
+		return "", fmt.Errorf("no base URL configured")
+	}
+	url := cfg.BaseURL + "/v1/releases/"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Authorization", "JWT "+cfg.APIKey)
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == 401 || resp.StatusCode == 403:
+		return "", fmt.Errorf("%v: API key rejected: %v", url, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", fmt.Errorf("%v: %v", url, resp.Status)
+	}
+	return "API key accepted by " + cfg.BaseURL, nil
+}
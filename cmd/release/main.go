@@ -2,19 +2,29 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto"
+	_ "crypto/sha256" // register crypto.SHA256
 	"crypto/sha512"
 	"debug/dwarf"
 	"debug/elf"
+	"encoding/binary"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gobuffalo/packr"
 
@@ -34,10 +44,18 @@ type Symbol struct {
 	Value uint64
 }
 
+// A Function represents an address range of a DWARF subprogram entry.
+type Function struct {
+	Name   string
+	LowPC  uint64
+	HighPC uint64
+}
+
 type languageMeta struct {
-	TopLevel string   `json:"absolute_path_prefix"`
-	Dwarf    []Dwarf  `json:"dwarf"`
-	Symbols  []Symbol `json:"symbols"`
+	TopLevel  string     `json:"absolute_path_prefix"`
+	Dwarf     []Dwarf    `json:"dwarf"`
+	Symbols   []Symbol   `json:"symbols"`
+	Functions []Function `json:"functions"`
 }
 
 // A Release represents a release of a customer's app to be sent to the backend.
@@ -46,12 +64,30 @@ type Release struct {
 	languageMeta `json:"language_meta"`
 	CommitHash   string  `json:"commit_hash"`
 	CheckSum     string  `json:"release"`
+	Algorithm    string  `json:"checksum_algorithm"`
+	BuildID      string  `json:"build_id,omitempty"`
 	Version      *string `json:"version,omitempty"`
+	Branch       string  `json:"branch,omitempty"`
+	Compiler     string  `json:"compiler,omitempty"`
+	BuildDate    string  `json:"build_date,omitempty"`
 }
 
-func usage() {
-	fmt.Printf("usage: %v deployfile\n", os.Args[0])
-	fmt.Printf("view OSS licenses: %v --licenses\n", os.Args[0])
+// checksums maps the names accepted by the -checksum flag to the hash
+// functions used to compute a release checksum. The client must use the same
+// algorithm to identify the executable at runtime.
+var checksums = map[string]crypto.Hash{
+	"sha256":     crypto.SHA256,
+	"sha512/224": crypto.SHA512_224,
+}
+
+// checksumNames returns the names accepted by the -checksum flag.
+func checksumNames() []string {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func licenses() {
@@ -73,7 +109,7 @@ func licenses() {
 func (rel *Release) symbolize(debugpath string) {
 	debugfile, err := elf.Open(debugpath)
 	if err != nil {
-		log.Println("Debug filename must be <deployfile>-dbg")
+		log.Println("debug file must be given or named <deployfile>-dbg")
 		log.Fatal(err)
 	}
 	defer debugfile.Close()
@@ -90,7 +126,7 @@ func (rel *Release) symbolize(debugpath string) {
 		})
 	}
 
-	// add DWARF line entries
+	// add DWARF functions and line entries
 	d, err := debugfile.DWARF()
 	if err != nil {
 		log.Fatal(err)
@@ -99,12 +135,22 @@ func (rel *Release) symbolize(debugpath string) {
 
 	for {
 		entry, err := r.Next()
+		if err != nil {
+			log.Fatal(err)
+		}
 		if entry == nil {
 			break
 		}
+		if entry.Tag == dwarf.TagSubprogram {
+			rel.function(d, entry)
+			continue
+		}
 		if entry.Tag != dwarf.TagCompileUnit {
 			continue
 		}
+		if rel.Compiler == "" && isCLanguage(entry) {
+			rel.Compiler, _ = entry.Val(dwarf.AttrProducer).(string)
+		}
 		lr, err := d.LineReader(entry)
 		if err != nil {
 			log.Fatal(err)
@@ -136,6 +182,247 @@ func (rel *Release) symbolize(debugpath string) {
 	}
 }
 
+// ntGNUBuildID is the note type of a GNU build-id note.
+const ntGNUBuildID = 3
+
+// cLanguages is the set of DW_AT_language values for C and C++.
+var cLanguages = map[int64]bool{
+	0x01: true, // DW_LANG_C89
+	0x02: true, // DW_LANG_C
+	0x04: true, // DW_LANG_C_plus_plus
+	0x0c: true, // DW_LANG_C99
+	0x19: true, // DW_LANG_C_plus_plus_03
+	0x1a: true, // DW_LANG_C_plus_plus_11
+	0x1d: true, // DW_LANG_C11
+	0x21: true, // DW_LANG_C_plus_plus_14
+	0x2a: true, // DW_LANG_C_plus_plus_17
+	0x2b: true, // DW_LANG_C_plus_plus_20
+	0x2c: true, // DW_LANG_C17
+}
+
+// isCLanguage reports whether the compile unit e was written in C or C++.
+// Startup code assembled with debug info, such as crt*.S, has an assembler
+// as its producer and should not be reported as the compiler.
+func isCLanguage(e *dwarf.Entry) bool {
+	lang, ok := e.Val(dwarf.AttrLanguage).(int64)
+	return ok && cLanguages[lang]
+}
+
+// buildID returns the hex-encoded contents of f's GNU build-id note, or the
+// empty string if f has none. Unlike the file checksum, the build-id survives
+// stripping and re-signing.
+func buildID(f *elf.File) (string, error) {
+	s := f.Section(".note.gnu.build-id")
+	if s == nil {
+		return "", nil
+	}
+	b, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	id, ok := parseBuildID(b, f.ByteOrder)
+	if !ok {
+		return "", fmt.Errorf("%v: malformed build-id note", s.Name)
+	}
+	return id, nil
+}
+
+// parseBuildID returns the hex-encoded descriptor of the GNU build-id note
+// in the note section contents b. ok is false if b holds no such note.
+func parseBuildID(b []byte, order binary.ByteOrder) (id string, ok bool) {
+	// A note consists of namesz, descsz and type words, followed by the
+	// name and the descriptor, each padded to a multiple of four bytes.
+	for len(b) >= 12 {
+		namesz := order.Uint32(b[0:4])
+		descsz := order.Uint32(b[4:8])
+		typ := order.Uint32(b[8:12])
+		b = b[12:]
+		// Check the sizes before rounding them up, which could overflow.
+		if uint64(namesz) > uint64(len(b)) || uint64(descsz) > uint64(len(b)) {
+			break
+		}
+		name := (int(namesz) + 3) &^ 3
+		desc := (int(descsz) + 3) &^ 3
+		if len(b) < name+desc {
+			break
+		}
+		if typ == ntGNUBuildID && string(b[:namesz]) == "GNU\x00" {
+			return fmt.Sprintf("%x", b[name:name+int(descsz)]), true
+		}
+		b = b[name+desc:]
+	}
+	return "", false
+}
+
+func (rel *Release) buildID(deployName string) {
+	f, err := elf.Open(deployName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	id, err := buildID(f)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	rel.BuildID = id
+}
+
+// attrLinkageName is DW_AT_linkage_name, which debug/dwarf names only as of
+// Go 1.14.
+const attrLinkageName dwarf.Attr = 0x6e
+
+// functionName returns the linkage name of the subprogram e, so that C++
+// overloads can be told apart, or its plain name if it has none. C++ members
+// defined outside their class and out-of-line instances of inline functions
+// carry their names on the entry referred to by DW_AT_specification or
+// DW_AT_abstract_origin, so those references are followed.
+func functionName(d *dwarf.Data, e *dwarf.Entry) string {
+	var name string
+	// Chains are short; the limit only guards against reference cycles.
+	for depth := 0; e != nil && depth < 8; depth++ {
+		if linkage, ok := e.Val(attrLinkageName).(string); ok {
+			return linkage
+		}
+		if n, ok := e.Val(dwarf.AttrName).(string); ok && name == "" {
+			name = n
+		}
+		off, ok := e.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		if !ok {
+			off, ok = e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		}
+		if !ok {
+			break
+		}
+		r := d.Reader()
+		r.Seek(off)
+		next, err := r.Next()
+		if err != nil {
+			log.Print(err)
+			break
+		}
+		e = next
+	}
+	return name
+}
+
+// function adds the address ranges of the subprogram entry e. Declarations
+// and abstract instances of inline functions have no ranges and are ignored.
+func (rel *Release) function(d *dwarf.Data, e *dwarf.Entry) {
+	ranges, err := d.Ranges(e)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if len(ranges) == 0 {
+		return
+	}
+	name := functionName(d, e)
+	if name == "" {
+		return
+	}
+	for _, r := range ranges {
+		rel.Functions = append(rel.Functions, Function{
+			Name:   name,
+			LowPC:  r[0],
+			HighPC: r[1],
+		})
+	}
+}
+
+// debugDir is where distributions install separate debug files.
+const debugDir = "/usr/lib/debug"
+
+// debugLink returns the file name and CRC-32 stored in f's .gnu_debuglink
+// section. ok is false if f has no valid debug link.
+func debugLink(f *elf.File) (name string, crc uint32, ok bool) {
+	s := f.Section(".gnu_debuglink")
+	if s == nil {
+		return "", 0, false
+	}
+	b, err := s.Data()
+	if err != nil {
+		return "", 0, false
+	}
+	return parseDebugLink(b, f.ByteOrder)
+}
+
+// parseDebugLink returns the file name and CRC-32 in the .gnu_debuglink
+// section contents b.
+func parseDebugLink(b []byte, order binary.ByteOrder) (name string, crc uint32, ok bool) {
+	// The NUL-terminated name is padded to a multiple of four bytes and
+	// followed by the checksum.
+	i := bytes.IndexByte(b, 0)
+	if i <= 0 {
+		return "", 0, false
+	}
+	off := (i + 4) &^ 3
+	if len(b) < off+4 {
+		return "", 0, false
+	}
+	return string(b[:i]), order.Uint32(b[off:]), true
+}
+
+// fileCRC returns the CRC-32 of the named file, as used by .gnu_debuglink.
+func fileCRC(name string) (uint32, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
+// findDebugFile returns the name of the file holding debug information for
+// deployName. Like gdb, it looks for a build-id path under debugDir and then
+// for the file named by the .gnu_debuglink section next to deployName, in
+// its .debug directory, and under debugDir. The <deployfile>-dbg convention
+// takes precedence over both.
+func findDebugFile(deployName string) (string, error) {
+	dbg := deployName + "-dbg"
+	if _, err := os.Stat(dbg); err == nil {
+		return dbg, nil
+	}
+
+	f, err := elf.Open(deployName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if id, err := buildID(f); err == nil && len(id) > 2 {
+		path := filepath.Join(debugDir, ".build-id", id[:2], id[2:]+".debug")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if name, crc, ok := debugLink(f); ok {
+		dir, err := filepath.Abs(filepath.Dir(deployName))
+		if err != nil {
+			return "", err
+		}
+		for _, path := range []string{
+			filepath.Join(dir, name),
+			filepath.Join(dir, ".debug", name),
+			filepath.Join(debugDir, dir, name),
+		} {
+			// A matching checksum also rules out deployName itself.
+			if sum, err := fileCRC(path); err == nil && sum == crc {
+				return path, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no debug file found for %v", deployName)
+}
+
 func hash(s *elf.Section) []byte {
 	r := s.Open()
 	h := sha512.New512_224()
@@ -152,7 +439,7 @@ func sectionsMatch(deployName, debugName string) bool {
 	}
 	defer deployfile.Close()
 
-	debugfile, err := elf.Open(deployName)
+	debugfile, err := elf.Open(debugName)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -160,7 +447,12 @@ func sectionsMatch(deployName, debugName string) bool {
 
 	// compare file sections
 	for _, deploysect := range deployfile.Sections {
-		if deploysect == nil || deploysect.Type == elf.SHT_STRTAB {
+		// SHT_NOBITS sections (e.g. .bss) occupy no space in the file.
+		// .gnu_debuglink is added to the deploy file after its separate
+		// debug file has been split off, so the debug file never has it.
+		if deploysect == nil || deploysect.Type == elf.SHT_STRTAB ||
+			deploysect.Type == elf.SHT_NOBITS ||
+			deploysect.Name == ".gnu_debuglink" {
 			continue
 		}
 
@@ -171,6 +463,12 @@ func sectionsMatch(deployName, debugName string) bool {
 			continue
 		}
 
+		// Separate debug files made with objcopy --only-keep-debug
+		// keep only the headers of non-debug sections.
+		if debugsect.Type == elf.SHT_NOBITS {
+			continue
+		}
+
 		if bytes.Compare(hash(deploysect), hash(debugsect)) != 0 {
 			log.Printf("section %-15v %-18v differs\n",
 				deploysect.Name, deploysect.Type)
@@ -180,7 +478,12 @@ func sectionsMatch(deployName, debugName string) bool {
 	return true
 }
 
-func (rel *Release) commitHash() {
+func (rel *Release) commitHash(commit string) {
+	if commit != "" {
+		rel.CommitHash = commit
+		return
+	}
+
 	c := exec.Command("git", "rev-parse", "HEAD")
 	out, err := c.CombinedOutput()
 	if err != nil {
@@ -190,6 +493,75 @@ func (rel *Release) commitHash() {
 	rel.CommitHash = strings.TrimSpace(string(out))
 }
 
+// branchVars are the environment variables in which common CI services
+// provide the branch being built.
+var branchVars = []string{
+	"CIRCLE_BRANCH",          // CircleCI
+	"GITHUB_HEAD_REF",        // GitHub Actions, pull requests
+	"GITHUB_REF_NAME",        // GitHub Actions
+	"CI_COMMIT_REF_NAME",     // GitLab CI
+	"TRAVIS_BRANCH",          // Travis CI
+	"BITBUCKET_BRANCH",       // Bitbucket Pipelines
+	"BUILDKITE_BRANCH",       // Buildkite
+	"BRANCH_NAME",            // Jenkins multibranch pipelines
+	"BUILD_SOURCEBRANCHNAME", // Azure Pipelines
+}
+
+// branch uses the given name, or else the branch provided by the CI
+// service, or else the checked-out git branch.
+func (rel *Release) branch(name string) {
+	if name != "" {
+		rel.Branch = name
+		return
+	}
+	for _, v := range branchVars {
+		if b := os.Getenv(v); b != "" {
+			rel.Branch = b
+			return
+		}
+	}
+
+	c := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	out, err := c.CombinedOutput()
+	if err != nil {
+		return
+	}
+
+	// A detached HEAD, as is common in CI, has no branch name.
+	if b := strings.TrimSpace(string(out)); b != "HEAD" {
+		rel.Branch = b
+	}
+}
+
+// buildDate uses the given RFC 3339 date, or else SOURCE_DATE_EPOCH as used
+// by reproducible builds, or else the modification time of the deploy file.
+// The modification time is a last resort, since stripping, copying and
+// restoring build artifacts all change it.
+func (rel *Release) buildDate(deployName, date string) {
+	var t time.Time
+	switch epoch := os.Getenv("SOURCE_DATE_EPOCH"); {
+	case date != "":
+		var err error
+		t, err = time.Parse(time.RFC3339, date)
+		if err != nil {
+			log.Fatalf("invalid build date: %v", err)
+		}
+	case epoch != "":
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid SOURCE_DATE_EPOCH: %v", err)
+		}
+		t = time.Unix(sec, 0)
+	default:
+		fi, err := os.Stat(deployName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t = fi.ModTime()
+	}
+	rel.BuildDate = t.UTC().Format(time.RFC3339)
+}
+
 func (rel *Release) topLevel() {
 	c := exec.Command("git", "rev-parse", "--show-toplevel")
 	out, err := c.CombinedOutput()
@@ -207,32 +579,49 @@ func (rel *Release) topLevel() {
 	rel.TopLevel = wd
 }
 
-func (rel *Release) release(deployName string) {
+func (rel *Release) release(deployName, algorithm string) {
 	f, err := os.Open(deployName)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer f.Close()
 
-	dh := sha512.New512_224()
+	dh := checksums[algorithm].New()
 	if _, err := io.Copy(dh, f); err != nil {
 		log.Fatal(err)
 	}
 	rel.CheckSum = fmt.Sprintf("%x", dh.Sum(nil))
+	rel.Algorithm = algorithm
 }
 
-func getConfig(baseURL string) config.Config {
-	cfg := config.GetConfig(baseURL)
+func loadConfig(baseURL, filename string) config.Config {
+	cfg, err := config.GetConfig(baseURL, filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+func getConfig(baseURL, filename string) config.Config {
+	cfg := loadConfig(baseURL, filename)
 	if !cfg.Valid() {
 		log.Fatal("incomplete configuration")
 	}
 	return cfg
 }
 
-func newRelease(deployName, appID, version string) *Release {
+// buildInfo holds release metadata given on the command line. Empty fields
+// other than Version are detected automatically.
+type buildInfo struct {
+	Version   string
+	Branch    string
+	Commit    string
+	BuildDate string
+}
+
+func newRelease(deployName, debugName, appID, algorithm string, info buildInfo) *Release {
 	rel := new(Release)
 	rel.AppID = appID
-	debugName := deployName + "-dbg"
 	rel.symbolize(debugName)
 
 	// reject ELF pairs with disparate sections
@@ -240,35 +629,151 @@ func newRelease(deployName, appID, version string) *Release {
 		os.Exit(1)
 	}
 
-	rel.commitHash()
+	rel.commitHash(info.Commit)
+	rel.branch(info.Branch)
 
-	if version != "" {
-		rel.Version = &version
+	if info.Version != "" {
+		rel.Version = &info.Version
 	}
 
 	rel.topLevel()
-	rel.release(deployName)
+	rel.release(deployName, algorithm)
+	rel.buildID(deployName)
+	rel.buildDate(deployName, info.BuildDate)
 	return rel
 }
 
-func post(rel *Release, cfg config.Config) {
-	b, err := json.MarshalIndent(rel, "", "    ")
-	if err != nil {
+// client is used for all requests to the Auklet API. Its transport matches
+// http.DefaultTransport, including its proxy support, but bounds connection
+// setup and the wait for a response. There is no limit on the exchange as a
+// whole, since large uploads over slow links can take a long time.
+var client = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: 2 * time.Minute,
+	},
+}
+
+// Failed requests are retried up to maxAttempts times, waiting retryDelay
+// before the first retry and doubling the delay after each one.
+const (
+	maxAttempts = 5
+	retryDelay  = 2 * time.Second
+)
+
+// retryable reports whether a request that returned resp and err should be
+// retried: network errors and server-side failures are likely transient.
+func retryable(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= 500
+}
+
+// compress returns b compressed with gzip.
+func compress(b []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
 		panic(err)
 	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
 
-	url := cfg.BaseURL + "/v1/releases/"
-	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+// progressSize is the body size above which do logs upload progress.
+const progressSize = 1 << 20
+
+// A progressReader logs the progress of reading an upload body in steps of
+// ten percent.
+type progressReader struct {
+	r     io.Reader
+	n     int
+	total int
+	next  int // next percentage to log
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += n
+	for p.next <= 100 && p.n*100 >= p.next*p.total {
+		log.Printf("uploaded %v%% (%v of %v bytes)\n", p.next, p.n, p.total)
+		p.next += 10
+	}
+	return n, err
+}
+
+// do sends a request with the given method, URL, body and header, retrying
+// transient failures.
+func do(method, url string, body []byte, header http.Header) (*http.Response, error) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > progressSize {
+			// Replace GetBody too, so that redirects are followed and
+			// report their progress as well.
+			req.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(&progressReader{
+					r:     bytes.NewReader(body),
+					total: len(body),
+					next:  10,
+				}), nil
+			}
+			req.Body, _ = req.GetBody()
+		}
+		req.Header = header
+		req.Header.Set("User-Agent", userAgent())
+		resp, err := client.Do(req)
+		if !retryable(resp, err) || attempt == maxAttempts {
+			return resp, err
+		}
+		if err != nil {
+			log.Print(err)
+		} else {
+			log.Print(resp.Status)
+			resp.Body.Close()
+		}
+		log.Printf("retrying in %v (attempt %v of %v)\n", delay, attempt+1, maxAttempts)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func post(rel *Release, cfg config.Config, gzipped bool) {
+	b, err := json.MarshalIndent(rel, "", "    ")
 	if err != nil {
 		panic(err)
 	}
-	req.Header.Add("content-type", "application/json")
-	req.Header.Add("Authorization", "JWT "+cfg.APIKey)
+	if gzipped {
+		n := len(b)
+		b = compress(b)
+		log.Printf("compressed release from %v to %v bytes\n", n, len(b))
+	} else {
+		log.Printf("release is %v bytes\n", len(b))
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	url := cfg.BaseURL + "/v1/releases/"
+	header := http.Header{}
+	header.Add("content-type", "application/json")
+	if gzipped {
+		header.Add("content-encoding", "gzip")
+	}
+	header.Add("Authorization", "JWT "+cfg.APIKey)
+	resp, err := do("POST", url, b, header)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
+	defer resp.Body.Close()
 
 	log.Printf("appid: %v\n", rel.AppID)
 	log.Printf("checksum: %v\n", rel.CheckSum)
@@ -277,42 +782,214 @@ func post(rel *Release, cfg config.Config) {
 	case 200:
 		log.Println("not created")
 	case 201: // created
-	case 502: // bad gateway
-		log.Fatal(url)
 	default:
+		// Anything else, including a final 5xx after retries, is a
+		// failed release and must fail the build.
 		b, _ := ioutil.ReadAll(resp.Body)
-		log.Print(string(b))
+		log.Fatalf("%v: %s", url, b)
 	}
 }
 
-var (
-	viewLicenses bool
-	version      string
-	baseURL      string
-)
+// show prints a summary of rel and the JSON that would be uploaded.
+func show(rel *Release) {
+	b, err := json.MarshalIndent(rel, "", "    ")
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("checksum: %v (%v)\n", rel.CheckSum, rel.Algorithm)
+	log.Printf("build id: %v\n", rel.BuildID)
+	log.Printf("%v symbols, %v line entries, %v functions\n",
+		len(rel.Symbols), len(rel.Dwarf), len(rel.Functions))
+	log.Printf("release is %v bytes\n", len(b))
+	fmt.Println(string(b))
+}
 
-func init() {
-	flag.BoolVar(&viewLicenses, "licenses", false, "view OSS licenses")
-	flag.StringVar(&version, "version", "", "user-defined version string")
-	flag.StringVar(&baseURL, "base-url", "", "Auklet API URL; do not change unless instructed by support")
+// verify reports whether the backend has a release matching the checksum of
+// the named executable. It uses the same endpoint as the Auklet client.
+// Responses other than 200 or 404 are failed requests and are fatal.
+func verify(deployName, algorithm string, cfg config.Config) bool {
+	rel := new(Release)
+	rel.release(deployName, algorithm)
+
+	url := cfg.BaseURL + "/check_releases/" + rel.CheckSum
+	header := http.Header{}
+	header.Add("Authorization", "JWT "+cfg.APIKey)
+	resp, err := do("GET", url, nil, header)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	log.Printf("checksum: %v\n", rel.CheckSum)
+	log.Print(resp.Status)
+	switch resp.StatusCode {
+	case 200:
+		return true
+	case 404:
+		return false
+	}
+	// Anything else, e.g. a rejected API key or a wrong base URL, must
+	// not be mistaken for a missing release.
+	b, _ := ioutil.ReadAll(resp.Body)
+	log.Fatalf("%v: %v: %s", url, resp.Status, b)
+	return false
 }
 
-func main() {
-	flag.Parse()
-	if viewLicenses {
+// configFlags adds the flags shared by commands that load a config to fs.
+func configFlags(fs *flag.FlagSet) (baseURL, filename *string) {
+	baseURL = fs.String("base-url", "", "Auklet API URL; do not change unless instructed by support")
+	filename = fs.String("config", "", "config file (default "+config.DefaultFile+" if it exists)")
+	return
+}
+
+func runRelease(args []string) {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	baseURL, configFile := configFlags(fs)
+	viewLicenses := fs.Bool("licenses", false, "view OSS licenses (same as the licenses command)")
+	var info buildInfo
+	fs.StringVar(&info.Version, "version", "", "user-defined version string")
+	fs.StringVar(&info.Branch, "branch", "", "branch the release was built from (default: detected from CI or git)")
+	fs.StringVar(&info.Commit, "commit", "", "commit the release was built from (default: git HEAD)")
+	fs.StringVar(&info.BuildDate, "build-date", "", "RFC 3339 build date (default: SOURCE_DATE_EPOCH or the deploy file's mtime)")
+	checksum := fs.String("checksum", "sha512/224", "checksum algorithm: "+strings.Join(checksumNames(), ", ")+
+		"; the Auklet client must be configured for the same algorithm")
+	gzipped := fs.Bool("gzip", false, "compress the upload with gzip")
+	dryRun := fs.Bool("dry-run", false, "print the release instead of uploading it")
+	verifyOnly := fs.Bool("verify", false, "check that deployfile has been released")
+	fs.Usage = func() {
+		fmt.Printf("usage: %v [release] [flags] deployfile [debugfile]\n", os.Args[0])
+		fmt.Printf("debugfile defaults to deployfile-dbg\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *viewLicenses {
 		licenses()
+		return
+	}
+
+	args = fs.Args()
+	if len(args) == 0 || len(args) > 2 {
+		fs.Usage()
 		os.Exit(1)
 	}
 
-	args := flag.Args()
-	if len(flag.Args()) == 0 {
-		usage()
+	log.Printf("Auklet Releaser version %s\n", versionString())
+
+	if _, ok := checksums[*checksum]; !ok {
+		log.Fatalf("unknown checksum algorithm %q; must be one of: %v",
+			*checksum, strings.Join(checksumNames(), ", "))
+	}
+
+	deployName := args[0]
+	if *verifyOnly {
+		if !verify(deployName, *checksum, getConfig(*baseURL, *configFile)) {
+			log.Fatalf("%v has not been released", deployName)
+		}
+		log.Printf("%v has been released", deployName)
+		return
+	}
+
+	var debugName string
+	if len(args) == 2 {
+		debugName = args[1]
+	} else {
+		var err error
+		debugName, err = findDebugFile(deployName)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *dryRun {
+		// Credentials are not needed to see what would be uploaded.
+		cfg := loadConfig(*baseURL, *configFile)
+		show(newRelease(deployName, debugName, cfg.AppID, *checksum, info))
+		return
+	}
+
+	cfg := getConfig(*baseURL, *configFile)
+	rel := newRelease(deployName, debugName, cfg.AppID, *checksum, info)
+	post(rel, cfg, *gzipped)
+}
+
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	baseURL, configFile := configFlags(fs)
+	fs.Usage = func() {
+		fmt.Printf("usage: %v doctor [flags] [deployfile]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	args = fs.Args()
+	if len(args) > 1 {
+		fs.Usage()
 		os.Exit(1)
 	}
+	var deployName string
+	if len(args) == 1 {
+		deployName = args[0]
+	}
+	if !doctor(*baseURL, *configFile, deployName) {
+		os.Exit(1)
+	}
+}
+
+func runVersion(args []string) {
+	fmt.Printf("Auklet Releaser version %s\n", versionString())
+}
+
+func runLicenses(args []string) {
+	licenses()
+}
+
+// A command is a subcommand of the releaser.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"release", "upload symbol information for an executable (default)", runRelease},
+		{"doctor", "check the configuration and environment", runDoctor},
+		{"version", "print the releaser version, commit and build date", runVersion},
+		{"licenses", "view OSS licenses", runLicenses},
+		{"help", "show this help", func([]string) { usage() }},
+	}
+}
 
-	log.Printf("Auklet Releaser version %s (%s)\n", Version, BuildDate)
+func usage() {
+	fmt.Printf("usage: %v <command> [flags] [args]\n\n", os.Args[0])
+	fmt.Printf("commands:\n")
+	for _, c := range commands {
+		fmt.Printf("  %-10v %v\n", c.name, c.summary)
+	}
+	fmt.Printf("\nIf no command is given, release is assumed. Run\n")
+	fmt.Printf("\"%v <command> -h\" for the flags of a command.\n", os.Args[0])
+}
 
-	cfg := getConfig(baseURL)
-	rel := newRelease(args[0], cfg.AppID, version)
-	post(rel, cfg)
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+	// -version is a release flag that takes a value, so it only means
+	// "print the version" when it stands alone.
+	if len(args) == 1 && (args[0] == "--version" || args[0] == "-version") {
+		runVersion(nil)
+		return
+	}
+	for _, c := range commands {
+		if args[0] == c.name {
+			c.run(args[1:])
+			return
+		}
+	}
+	// Releases were made without a command name before commands existed.
+	runRelease(args)
 }
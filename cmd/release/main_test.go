@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestSymbolizeFunctions(t *testing.T) {
+	rel := new(Release)
+	rel.symbolize("testdata/functions")
+
+	got := make(map[string]bool)
+	for _, f := range rel.Functions {
+		if f.LowPC >= f.HighPC {
+			t.Errorf("%v: empty range [%#x, %#x)", f.Name, f.LowPC, f.HighPC)
+		}
+		got[f.Name] = true
+	}
+	for _, name := range []string{
+		"main",
+		"_ZN1S1fEi", // defined outside its class; named via DW_AT_specification
+		"_ZN1S1fEd", // overload of the above
+		"twice",     // out-of-line instance; named via DW_AT_abstract_origin
+	} {
+		if !got[name] {
+			t.Errorf("missing function %v; got %v", name, rel.Functions)
+		}
+	}
+	if !strings.HasPrefix(rel.Compiler, "GNU C++") {
+		t.Errorf("got compiler %q, want GNU C++", rel.Compiler)
+	}
+}
+
+// note encodes an ELF note with the given name, type and descriptor.
+func note(name string, typ uint32, desc []byte) []byte {
+	pad := func(b []byte) []byte {
+		return append(b, make([]byte, (4-len(b)%4)%4)...)
+	}
+	b := make([]byte, 12)
+	binary.LittleEndian.PutUint32(b[0:], uint32(len(name)))
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(desc)))
+	binary.LittleEndian.PutUint32(b[8:], typ)
+	b = append(b, pad([]byte(name))...)
+	return append(b, pad(desc)...)
+}
+
+func TestParseBuildID(t *testing.T) {
+	id := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	huge := note("GNU\x00", ntGNUBuildID, id)
+	binary.LittleEndian.PutUint32(huge[0:], 0xfffffffd)
+	cases := []struct {
+		name string
+		b    []byte
+		want string
+		ok   bool
+	}{
+		{"build-id", note("GNU\x00", ntGNUBuildID, id), "deadbeef01", true},
+		{"after other note", append(note("Go\x00\x00", 4, []byte("x")),
+			note("GNU\x00", ntGNUBuildID, id)...), "deadbeef01", true},
+		{"empty", nil, "", false},
+		{"wrong type", note("GNU\x00", 1, id), "", false},
+		{"wrong name", note("XYZ\x00", ntGNUBuildID, id), "", false},
+		{"truncated", note("GNU\x00", ntGNUBuildID, id)[:18], "", false},
+		{"overflowing namesz", huge, "", false},
+	}
+	for _, c := range cases {
+		got, ok := parseBuildID(c.b, binary.LittleEndian)
+		if got != c.want || ok != c.ok {
+			t.Errorf("%v: got %q, %v; want %q, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestParseDebugLink(t *testing.T) {
+	link := func(name string, crc uint32) []byte {
+		b := append([]byte(name), 0)
+		b = append(b, make([]byte, (4-len(b)%4)%4)...)
+		c := make([]byte, 4)
+		binary.LittleEndian.PutUint32(c, crc)
+		return append(b, c...)
+	}
+	cases := []struct {
+		name     string
+		b        []byte
+		wantName string
+		wantCRC  uint32
+		ok       bool
+	}{
+		{"padded", link("app.debug", 0x12345678), "app.debug", 0x12345678, true},
+		{"unpadded", link("abc", 7), "abc", 7, true},
+		{"empty", nil, "", 0, false},
+		{"no name", link("", 7), "", 0, false},
+		{"no terminator", []byte("app.debug"), "", 0, false},
+		{"no crc", link("app.debug", 1)[:12], "", 0, false},
+	}
+	for _, c := range cases {
+		name, crc, ok := parseDebugLink(c.b, binary.LittleEndian)
+		if name != c.wantName || crc != c.wantCRC || ok != c.ok {
+			t.Errorf("%v: got %q, %#x, %v; want %q, %#x, %v",
+				c.name, name, crc, ok, c.wantName, c.wantCRC, c.ok)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Fixture for TestSymbolizeFunctions. Rebuild with:
+//
+//	g++ -g -gdwarf-4 -O1 -fno-pie -no-pie -o functions functions.cc
+struct S {
+	int f(int);
+	int f(double);
+};
+
+int S::f(int x) { return x + 1; }
+int S::f(double x) { return (int)x + 2; }
+
+static inline int twice(int x) { return 2 * x; }
+
+int (*volatile fp)(int) = twice;
+
+int main(int argc, char **argv)
+{
+	S s;
+	return s.f(argc) + s.f(1.5) + twice(argc) + fp(argc);
+}
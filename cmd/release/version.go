@@ -1,7 +1,22 @@
 package main
 
+import "fmt"
+
 // BuildDate is provided at compile-time; DO NOT MODIFY.
 var BuildDate = "no timestamp"
 
 // Version is provided at compile-time; DO NOT MODIFY.
 var Version = "local-build"
+
+// Commit is provided at compile-time; DO NOT MODIFY.
+var Commit = "unknown commit"
+
+// versionString describes this build of the releaser.
+func versionString() string {
+	return fmt.Sprintf("%s (%s, %s)", Version, Commit, BuildDate)
+}
+
+// userAgent identifies this build of the releaser in requests.
+func userAgent() string {
+	return "Auklet-Releaser/" + Version + " (" + Commit + ")"
+}
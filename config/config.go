@@ -2,13 +2,27 @@
 package config
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Production defines the base URL for the production environment.
 const Production = "https://api.auklet.io"
 
+// DefaultFile is the configuration file read when none is given explicitly.
+// It is separate from the Auklet client's configuration, whose keys the
+// releaser does not know.
+const DefaultFile = "/etc/auklet/releaser.toml"
+
 // A Config represents parameters of a releaser invocation.
 type Config struct {
 	BaseURL string
@@ -16,25 +30,188 @@ type Config struct {
 	AppID   string
 }
 
-// GetConfig returns a config object whose BaseURL is dependent upon CLI args
-// or env vars.
-func GetConfig(fromcli string) Config {
-	var baseURL string
-	if fromcli != "" {
-		baseURL = fromcli
-	} else {
-		fromenv := os.Getenv("AUKLET_BASE_URL")
-		if fromenv != "" {
-			baseURL = fromenv
-		} else {
-			baseURL = Production
+// GetConfig returns a config object whose fields are taken from, in
+// decreasing order of precedence, CLI args, env vars, and a config file.
+// If filename is empty, DefaultFile is read if it exists.
+func GetConfig(fromcli, filename string) (Config, error) {
+	c, err := fromFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = Production
+	}
+	override(&c.BaseURL, os.Getenv("AUKLET_BASE_URL"))
+	override(&c.BaseURL, fromcli)
+	apiKey, err := secret("AUKLET_API_KEY")
+	if err != nil {
+		return Config{}, err
+	}
+	override(&c.APIKey, apiKey)
+	override(&c.AppID, os.Getenv("AUKLET_APP_ID"))
+	return c, nil
+}
+
+// secret returns the value of the env var name. If it is empty, the value is
+// read from the file named by the env var name_FILE or, when running under
+// systemd with LoadCredential=, from the credential called name. This keeps
+// the secret out of the process environment, which is readable by other
+// processes of the same user.
+func secret(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	filename := os.Getenv(name + "_FILE")
+	if filename == "" {
+		dir := os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", nil
+		}
+		filename = filepath.Join(dir, name)
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return "", nil
+		}
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// override sets *field to value if value is not empty.
+func override(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+func fromFile(filename string) (Config, error) {
+	if filename == "" {
+		if _, err := os.Stat(DefaultFile); os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		filename = DefaultFile
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%v: %v", filename, err)
+	}
+	return c, nil
+}
+
+// Parse reads a config file. The file format is the subset of TOML
+// consisting of comments and top-level keys with single-line basic or
+// literal string values:
+//
+//	# Auklet releaser configuration
+//	base_url = "https://api.auklet.io"
+//	api_key = '...'
+//	app_id = "..." # production
+func Parse(r io.Reader) (c Config, err error) {
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return c, fmt.Errorf("line %v: expected key = \"value\"", n)
+		}
+		key := strings.TrimSpace(kv[0])
+		value, err := parseString(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return c, fmt.Errorf("line %v: value of %v: %v", n, key, err)
+		}
+		switch key {
+		case "base_url":
+			c.BaseURL = value
+		case "api_key":
+			c.APIKey = value
+		case "app_id":
+			c.AppID = value
+		default:
+			return c, fmt.Errorf("line %v: unknown key %v", n, key)
+		}
+	}
+	return c, s.Err()
+}
+
+// parseString parses a TOML basic or literal string at the start of v, which
+// may only be followed by a comment.
+func parseString(v string) (string, error) {
+	var value, rest string
+	switch {
+	case strings.HasPrefix(v, `"""`), strings.HasPrefix(v, "'''"):
+		return "", fmt.Errorf("multi-line strings are not supported")
+	case strings.HasPrefix(v, "'"):
+		// Literal strings have no escapes.
+		i := strings.IndexByte(v[1:], '\'')
+		if i < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		value, rest = v[1:1+i], v[2+i:]
+	case strings.HasPrefix(v, `"`):
+		var buf bytes.Buffer
+		i := 1
+		for ; i < len(v) && v[i] != '"'; i++ {
+			if v[i] != '\\' {
+				buf.WriteByte(v[i])
+				continue
+			}
+			i++
+			if i == len(v) {
+				break
+			}
+			switch v[i] {
+			case 'b':
+				buf.WriteByte('\b')
+			case 't':
+				buf.WriteByte('\t')
+			case 'n':
+				buf.WriteByte('\n')
+			case 'f':
+				buf.WriteByte('\f')
+			case 'r':
+				buf.WriteByte('\r')
+			case '"', '\\':
+				buf.WriteByte(v[i])
+			case 'u', 'U':
+				size := 4
+				if v[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(v) {
+					return "", fmt.Errorf("short unicode escape")
+				}
+				r, err := strconv.ParseUint(v[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", fmt.Errorf("invalid unicode escape \\%v", v[i:i+1+size])
+				}
+				buf.WriteRune(rune(r))
+				i += size
+			default:
+				return "", fmt.Errorf("invalid escape \\%c", v[i])
+			}
+		}
+		if i >= len(v) {
+			return "", fmt.Errorf("unterminated string")
 		}
+		value, rest = buf.String(), v[i+1:]
+	default:
+		return "", fmt.Errorf("must be a quoted string")
 	}
-	return Config{
-		BaseURL: baseURL,
-		APIKey:  os.Getenv("AUKLET_API_KEY"),
-		AppID:   os.Getenv("AUKLET_APP_ID"),
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after string", rest)
 	}
+	return value, nil
 }
 
 // Valid returns true if c has no empty fields, false otherwise.
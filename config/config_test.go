@@ -1,6 +1,10 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -9,11 +13,11 @@ func TestValid(t *testing.T) {
 	if c.Valid() {
 		t.Fail()
 	}
-	c.BaseUrl = "not empty"
+	c.BaseURL = "not empty"
 	if c.Valid() {
 		t.Fail()
 	}
-	c.AppId = "not empty"
+	c.AppID = "not empty"
 	if c.Valid() {
 		t.Fail()
 	}
@@ -22,3 +26,114 @@ func TestValid(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input string
+		want  Config
+		ok    bool
+	}{
+		{input: "", ok: true},
+		{
+			input: "# comment\n\nbase_url = \"u\"\napi_key=\"k\"\n  app_id = \"a\"\n",
+			want:  Config{BaseURL: "u", APIKey: "k", AppID: "a"},
+			ok:    true,
+		},
+		{
+			input: "app_id = \"x\" # prod\napi_key = 'k\\n' # literal\nbase_url = \"a\\tb\\u00e9\"\n",
+			want:  Config{AppID: "x", APIKey: `k\n`, BaseURL: "a\tb\u00e9"},
+			ok:    true,
+		},
+		{input: "app_id = 'x'", want: Config{AppID: "x"}, ok: true},
+		{input: "app_id = 'x#y'", want: Config{AppID: "x#y"}, ok: true},
+		{input: "app_id = \"x\\\"y\"", want: Config{AppID: `x"y`}, ok: true},
+		{input: "app_id"},
+		{input: "app_id = a"},
+		{input: "app_id = \"x\" y"},
+		{input: "app_id = \"x"},
+		{input: "app_id = 'x"},
+		{input: "app_id = \"\\q\""},
+		{input: "app_id = \"\"\"x\"\"\""},
+		{input: "unknown = \"x\""},
+	}
+	for i, c := range cases {
+		got, err := Parse(strings.NewReader(c.input))
+		if (err == nil) != c.ok {
+			t.Errorf("case %v: got error %v, want ok = %v", i, err, c.ok)
+		}
+		if c.ok && got != c.want {
+			t.Errorf("case %v: got %+v, want %+v", i, got, c.want)
+		}
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("base_url = \"file\"\napi_key = \"file\"\napp_id = \"file\"\n")
+	f.Close()
+
+	os.Setenv("AUKLET_BASE_URL", "env")
+	os.Setenv("AUKLET_API_KEY", "env")
+	os.Setenv("AUKLET_APP_ID", "")
+	defer os.Unsetenv("AUKLET_BASE_URL")
+	defer os.Unsetenv("AUKLET_API_KEY")
+	defer os.Unsetenv("AUKLET_APP_ID")
+
+	got, err := GetConfig("cli", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{BaseURL: "cli", APIKey: "env", AppID: "file"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := GetConfig("", f.Name()+"-missing"); err == nil {
+		t.Error("expected error for missing config file")
+	}
+}
+
+func TestSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "AUKLET_API_KEY"), []byte("cred\n"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "key"), []byte("file\n"), 0600)
+	defer os.Unsetenv("AUKLET_API_KEY")
+	defer os.Unsetenv("AUKLET_API_KEY_FILE")
+	defer os.Unsetenv("CREDENTIALS_DIRECTORY")
+
+	cases := []struct {
+		env, file, credentials string
+		want                   string
+	}{
+		{want: ""},
+		{credentials: dir, want: "cred"},
+		{file: filepath.Join(dir, "key"), credentials: dir, want: "file"},
+		{env: "env", file: filepath.Join(dir, "key"), credentials: dir, want: "env"},
+	}
+	for i, c := range cases {
+		os.Setenv("AUKLET_API_KEY", c.env)
+		os.Setenv("AUKLET_API_KEY_FILE", c.file)
+		os.Setenv("CREDENTIALS_DIRECTORY", c.credentials)
+		got, err := secret("AUKLET_API_KEY")
+		if err != nil {
+			t.Errorf("case %v: %v", i, err)
+		}
+		if got != c.want {
+			t.Errorf("case %v: got %q, want %q", i, got, c.want)
+		}
+	}
+
+	os.Setenv("AUKLET_API_KEY", "")
+	os.Setenv("AUKLET_API_KEY_FILE", filepath.Join(dir, "missing"))
+	if _, err := secret("AUKLET_API_KEY"); err == nil {
+		t.Error("expected error for missing AUKLET_API_KEY_FILE")
+	}
+}